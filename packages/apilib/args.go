@@ -0,0 +1,256 @@
+package apilib
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+	"github.com/iotaledger/wasp/packages/kv"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+)
+
+// ErrWrongArgType is returned by convertArgs when a value in the vars map
+// has a Go type that can't be encoded, identifying the key and the Go type
+// that was rejected. A negative *big.Int is rejected with a plain error.
+type ErrWrongArgType struct {
+	Key    string
+	GoType string
+}
+
+func (e *ErrWrongArgType) Error() string {
+	return fmt.Sprintf("wrong argument for key '%s': unsupported Go type %s", e.Key, e.GoType)
+}
+
+// lenKey is the sub-key under which the element count of an encoded slice
+// is stored, e.g. "list" -> "list.len", "list.0", "list.1", ...
+func lenKey(key kv.Key) kv.Key {
+	return key + ".len"
+}
+
+func elemKey(key kv.Key, i int) kv.Key {
+	return kv.Key(fmt.Sprintf("%s.%d", key, i))
+}
+
+// convertArgs encodes a map of Go values into a kv.Map, so it can be
+// attached as arguments to a request block. In addition to the scalar
+// types supported previously (ints, string, []byte, *hashing.HashValue,
+// *address.Address, *balance.Color) it supports bool, *big.Int encoded as
+// canonical big-endian bytes with a length prefix, homogeneous slices
+// ([]int64, []string, [][]byte) encoded under indexed sub-keys with an
+// element-count prefix, and nested map[string]interface{}, encoded with a
+// path-joined key scheme (e.g. "parent.child.field").
+func convertArgs(vars map[string]interface{}) (kv.Map, error) {
+	args := kv.NewMap()
+	if err := encodeArgs(args, "", vars); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func encodeArgs(args kv.Map, prefix string, vars map[string]interface{}) error {
+	codec := args.Codec()
+	for k, v := range vars {
+		fullKey := k
+		if prefix != "" {
+			fullKey = prefix + "." + k
+		}
+		key := kv.Key(fullKey)
+		switch vt := v.(type) {
+		case bool:
+			i := int64(0)
+			if vt {
+				i = 1
+			}
+			codec.SetInt64(key, i)
+		case int:
+			codec.SetInt64(key, int64(vt))
+		case byte:
+			codec.SetInt64(key, int64(vt))
+		case int16:
+			codec.SetInt64(key, int64(vt))
+		case int32:
+			codec.SetInt64(key, int64(vt))
+		case int64:
+			codec.SetInt64(key, vt)
+		case uint16:
+			codec.SetInt64(key, int64(vt))
+		case uint32:
+			codec.SetInt64(key, int64(vt))
+		case uint64:
+			codec.SetInt64(key, int64(vt))
+		case string:
+			codec.SetString(key, vt)
+		case []byte:
+			codec.Set(key, vt)
+		case *big.Int:
+			if vt.Sign() < 0 {
+				return fmt.Errorf("wrong argument for key '%s': negative *big.Int is not supported by the canonical encoding", fullKey)
+			}
+			codec.Set(key, encodeBigInt(vt))
+		case *hashing.HashValue:
+			codec.SetHashValue(key, vt)
+		case *address.Address:
+			codec.Set(key, vt.Bytes())
+		case *balance.Color:
+			codec.Set(key, vt.Bytes())
+		case []int64:
+			codec.SetInt64(lenKey(key), int64(len(vt)))
+			for i, elem := range vt {
+				codec.SetInt64(elemKey(key, i), elem)
+			}
+		case []string:
+			codec.SetInt64(lenKey(key), int64(len(vt)))
+			for i, elem := range vt {
+				codec.SetString(elemKey(key, i), elem)
+			}
+		case [][]byte:
+			codec.SetInt64(lenKey(key), int64(len(vt)))
+			for i, elem := range vt {
+				codec.Set(elemKey(key, i), elem)
+			}
+		case map[string]interface{}:
+			if err := encodeArgs(args, fullKey, vt); err != nil {
+				return err
+			}
+		default:
+			return &ErrWrongArgType{Key: fullKey, GoType: fmt.Sprintf("%T", v)}
+		}
+	}
+	return nil
+}
+
+// encodeBigInt encodes n as a 4-byte big-endian length prefix followed by
+// its big-endian magnitude bytes. Negative numbers are not supported.
+func encodeBigInt(n *big.Int) []byte {
+	b := n.Bytes()
+	ret := make([]byte, 4+len(b))
+	ret[0] = byte(len(b) >> 24)
+	ret[1] = byte(len(b) >> 16)
+	ret[2] = byte(len(b) >> 8)
+	ret[3] = byte(len(b))
+	copy(ret[4:], b)
+	return ret
+}
+
+// decodeBigInt is the inverse of encodeBigInt.
+func decodeBigInt(b []byte) (*big.Int, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("decodeBigInt: buffer too short")
+	}
+	length := int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	if len(b) != 4+length {
+		return nil, fmt.Errorf("decodeBigInt: length mismatch")
+	}
+	return new(big.Int).SetBytes(b[4:]), nil
+}
+
+// DecodeArgs reads back values from a kv.Map produced by convertArgs: given
+// the expected Go type for each key, it returns a map of the decoded values.
+// Keys are the keys as stored, so a value from a nested map is looked up by
+// its path-joined key (e.g. "parent.child.field"); nested maps are not
+// rebuilt. Only bool, int64, string, []byte, *big.Int, []int64, []string and
+// [][]byte are decoded; any other sample type yields an *ErrWrongArgType.
+// It is exported so that tests and clients outside this package can
+// round-trip arguments built with convertArgs.
+func DecodeArgs(args kv.Map, types map[string]interface{}) (map[string]interface{}, error) {
+	codec := args.Codec()
+	ret := make(map[string]interface{})
+	for k, sample := range types {
+		key := kv.Key(k)
+		var err error
+		switch sample.(type) {
+		case bool:
+			var i int64
+			i, _, err = codec.GetInt64(key)
+			ret[k] = i != 0
+		case int64:
+			ret[k], _, err = codec.GetInt64(key)
+		case string:
+			ret[k], _, err = codec.GetString(key)
+		case []byte:
+			ret[k], _, err = codec.Get(key)
+		case *big.Int:
+			var b []byte
+			b, _, err = codec.Get(key)
+			if err == nil {
+				ret[k], err = decodeBigInt(b)
+			}
+		case []int64:
+			ret[k], err = decodeInt64Slice(codec, key)
+		case []string:
+			ret[k], err = decodeStringSlice(codec, key)
+		case [][]byte:
+			ret[k], err = decodeBytesSlice(codec, key)
+		default:
+			err = &ErrWrongArgType{Key: k, GoType: fmt.Sprintf("%T", sample)}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// maxDecodedSliceLen bounds the element count accepted out of a ".len" kv
+// entry. The entry may come from untrusted smart-contract input, so it must
+// be validated before being passed to make(), which panics on a negative or
+// absurdly large length.
+const maxDecodedSliceLen = 1 << 20
+
+func decodeSliceLen(codec kv.Codec, key kv.Key) (int, error) {
+	n, _, err := codec.GetInt64(lenKey(key))
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > maxDecodedSliceLen {
+		return 0, fmt.Errorf("decode %s: invalid slice length %d", key, n)
+	}
+	return int(n), nil
+}
+
+func decodeInt64Slice(codec kv.Codec, key kv.Key) ([]int64, error) {
+	n, err := decodeSliceLen(codec, key)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]int64, n)
+	for i := range ret {
+		ret[i], _, err = codec.GetInt64(elemKey(key, i))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func decodeStringSlice(codec kv.Codec, key kv.Key) ([]string, error) {
+	n, err := decodeSliceLen(codec, key)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, n)
+	for i := range ret {
+		ret[i], _, err = codec.GetString(elemKey(key, i))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func decodeBytesSlice(codec kv.Codec, key kv.Key) ([][]byte, error) {
+	n, err := decodeSliceLen(codec, key)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][]byte, n)
+	for i := range ret {
+		ret[i], _, err = codec.Get(elemKey(key, i))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,148 @@
+package apilib
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/iotaledger/wasp/packages/hashing"
+
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
+	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
+)
+
+func TestConvertArgsScalars(t *testing.T) {
+	var hv hashing.HashValue
+	var addr address.Address
+	color := balance.ColorNew
+
+	cases := []struct {
+		name string
+		vars map[string]interface{}
+	}{
+		{"bool", map[string]interface{}{"flag": true}},
+		{"int", map[string]interface{}{"n": int(42)}},
+		{"int64", map[string]interface{}{"n": int64(42)}},
+		{"string", map[string]interface{}{"s": "hello"}},
+		{"bytes", map[string]interface{}{"b": []byte{1, 2, 3}}},
+		{"bigint", map[string]interface{}{"bi": big.NewInt(123456789)}},
+		{"hash", map[string]interface{}{"h": &hv}},
+		{"address", map[string]interface{}{"a": &addr}},
+		{"color", map[string]interface{}{"c": &color}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args, err := convertArgs(c.vars)
+			if err != nil {
+				t.Fatalf("convertArgs failed: %v", err)
+			}
+			if args == nil {
+				t.Fatalf("convertArgs returned nil map")
+			}
+		})
+	}
+}
+
+func TestConvertArgsSlices(t *testing.T) {
+	vars := map[string]interface{}{
+		"ints":    []int64{1, 2, 3},
+		"strs":    []string{"a", "b"},
+		"byteArr": [][]byte{{1}, {2, 3}},
+	}
+	args, err := convertArgs(vars)
+	if err != nil {
+		t.Fatalf("convertArgs failed: %v", err)
+	}
+	decoded, err := DecodeArgs(args, map[string]interface{}{
+		"ints":    []int64{},
+		"strs":    []string{},
+		"byteArr": [][]byte{},
+	})
+	if err != nil {
+		t.Fatalf("decodeArgs failed: %v", err)
+	}
+	ints := decoded["ints"].([]int64)
+	if len(ints) != 3 || ints[0] != 1 || ints[1] != 2 || ints[2] != 3 {
+		t.Fatalf("unexpected decoded ints: %v", ints)
+	}
+	strs := decoded["strs"].([]string)
+	if len(strs) != 2 || strs[0] != "a" || strs[1] != "b" {
+		t.Fatalf("unexpected decoded strs: %v", strs)
+	}
+}
+
+func TestDecodeArgsRejectsMalformedSliceLen(t *testing.T) {
+	args, err := convertArgs(map[string]interface{}{"ints": []int64{1, 2, 3}})
+	if err != nil {
+		t.Fatalf("convertArgs failed: %v", err)
+	}
+	codec := args.Codec()
+	codec.SetInt64(lenKey("ints"), -1)
+	if _, err := DecodeArgs(args, map[string]interface{}{"ints": []int64{}}); err == nil {
+		t.Fatalf("expected error for negative slice length")
+	}
+	codec.SetInt64(lenKey("ints"), maxDecodedSliceLen+1)
+	if _, err := DecodeArgs(args, map[string]interface{}{"ints": []int64{}}); err == nil {
+		t.Fatalf("expected error for oversized slice length")
+	}
+}
+
+func TestConvertArgsNestedMap(t *testing.T) {
+	vars := map[string]interface{}{
+		"parent": map[string]interface{}{
+			"child": map[string]interface{}{
+				"field": "value",
+			},
+		},
+	}
+	args, err := convertArgs(vars)
+	if err != nil {
+		t.Fatalf("convertArgs failed: %v", err)
+	}
+	decoded, err := DecodeArgs(args, map[string]interface{}{
+		"parent.child.field": "",
+	})
+	if err != nil {
+		t.Fatalf("decodeArgs failed: %v", err)
+	}
+	if decoded["parent.child.field"] != "value" {
+		t.Fatalf("unexpected decoded value: %v", decoded["parent.child.field"])
+	}
+}
+
+func TestConvertArgsBigIntRoundTrip(t *testing.T) {
+	vars := map[string]interface{}{"amount": big.NewInt(987654321)}
+	args, err := convertArgs(vars)
+	if err != nil {
+		t.Fatalf("convertArgs failed: %v", err)
+	}
+	decoded, err := DecodeArgs(args, map[string]interface{}{"amount": big.NewInt(0)})
+	if err != nil {
+		t.Fatalf("decodeArgs failed: %v", err)
+	}
+	got := decoded["amount"].(*big.Int)
+	if got.Cmp(big.NewInt(987654321)) != 0 {
+		t.Fatalf("bigint round-trip mismatch: got %s", got.String())
+	}
+}
+
+func TestConvertArgsRejectsNegativeBigInt(t *testing.T) {
+	_, err := convertArgs(map[string]interface{}{"amount": big.NewInt(-5)})
+	if err == nil {
+		t.Fatalf("expected error for negative *big.Int")
+	}
+}
+
+func TestConvertArgsRejectsUnsupportedType(t *testing.T) {
+	type unsupported struct{ X int }
+	_, err := convertArgs(map[string]interface{}{"bad": unsupported{X: 1}})
+	if err == nil {
+		t.Fatalf("expected error for unsupported type")
+	}
+	typeErr, ok := err.(*ErrWrongArgType)
+	if !ok {
+		t.Fatalf("expected *ErrWrongArgType, got %T", err)
+	}
+	if typeErr.Key != "bad" {
+		t.Fatalf("expected key 'bad', got %s", typeErr.Key)
+	}
+}
@@ -1,13 +1,10 @@
 package apilib
 
 import (
-	"errors"
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/address/signaturescheme"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
-	"github.com/iotaledger/wasp/packages/hashing"
-	"github.com/iotaledger/wasp/packages/kv"
 	"github.com/iotaledger/wasp/packages/nodeclient"
 	"github.com/iotaledger/wasp/packages/sctransaction"
 	"github.com/iotaledger/wasp/packages/sctransaction/txbuilder"
@@ -21,7 +18,7 @@ type RequestBlockParams struct {
 	RequestCode     sctransaction.RequestCode
 	Timelock        uint32
 	Transfer        map[balance.Color]int64 // should not not include request token. It is added automatically
-	Vars            map[string]interface{}  ` `
+	Vars            map[string]interface{}
 }
 
 type CreateRequestTransactionParams struct {
@@ -52,9 +49,9 @@ func CreateRequestTransaction(par CreateRequestTransactionParams) (*sctransactio
 		reqBlk := sctransaction.NewRequestBlock(*blockPar.TargetSCAddress, blockPar.RequestCode).
 			WithTimelock(blockPar.Timelock)
 
-		args := convertArgs(blockPar.Vars)
-		if args == nil {
-			return nil, errors.New("wrong arguments")
+		args, err := convertArgs(blockPar.Vars)
+		if err != nil {
+			return nil, err
 		}
 		reqBlk.SetArgs(args)
 
@@ -114,42 +111,3 @@ func CreateRequestTransaction(par CreateRequestTransactionParams) (*sctransactio
 
 	return tx, nil
 }
-
-func convertArgs(vars map[string]interface{}) kv.Map {
-	args := kv.NewMap()
-	codec := args.Codec()
-	for k, v := range vars {
-		key := kv.Key(k)
-		switch vt := v.(type) {
-		case int:
-			codec.SetInt64(key, int64(vt))
-		case byte:
-			codec.SetInt64(key, int64(vt))
-		case int16:
-			codec.SetInt64(key, int64(vt))
-		case int32:
-			codec.SetInt64(key, int64(vt))
-		case int64:
-			codec.SetInt64(key, vt)
-		case uint16:
-			codec.SetInt64(key, int64(vt))
-		case uint32:
-			codec.SetInt64(key, int64(vt))
-		case uint64:
-			codec.SetInt64(key, int64(vt))
-		case string:
-			codec.SetString(key, vt)
-		case []byte:
-			codec.Set(key, vt)
-		case *hashing.HashValue:
-			args.Codec().SetHashValue(key, vt)
-		case *address.Address:
-			args.Codec().Set(key, vt.Bytes())
-		case *balance.Color:
-			args.Codec().Set(key, vt.Bytes())
-		default:
-			return nil
-		}
-	}
-	return args
-}
@@ -1,7 +1,6 @@
 package runvm
 
 import (
-	"errors"
 	"fmt"
 	"github.com/iotaledger/goshimmer/dapps/valuetransfers/packages/balance"
 	"github.com/iotaledger/hive.go/daemon"
@@ -11,22 +10,40 @@ import (
 	"github.com/iotaledger/wasp/packages/state"
 	"github.com/iotaledger/wasp/packages/vm"
 	"github.com/iotaledger/wasp/packages/vm/vmnil"
-	"sync"
 )
 
 // PluginName is the name of the NodeConn plugin.
 const PluginName = "VM"
 
+// builtin program hashes with factories registered at init instead of Wasm
+// bytecode: sc1 is handled by vmnil, sc2 and sc3 report that no VM is
+// implemented for them
+const (
+	sc1ProgramHash = "KSoWFbHwZuHG8B8HVcYVKR4WYVQ7MpoqeaXgKWfkBMF"
+	sc2ProgramHash = "7xmPcECfZsSQq5eq7GCucuxmL2QpsgYwTjusuQcoK9GE"
+	sc3ProgramHash = "2tx7z36m9EhX3xBRGmEUD4FwTyP6R66zGPYY53EWc87k"
+)
+
 var (
 	// Plugin is the plugin instance of the database plugin.
 	Plugin = node.NewPlugin(PluginName, node.Enabled, configure, run)
 	log    *logger.Logger
 
-	vmDaemon        = daemon.New()
-	processors      = make(map[string]vm.Processor)
-	processorsMutex sync.RWMutex
+	vmDaemon = daemon.New()
 )
 
+func init() {
+	RegisterFactory(sc1ProgramHash, func(_ string) (vm.Processor, error) {
+		return vmnil.New(), nil
+	})
+	RegisterFactory(sc2ProgramHash, func(programHash string) (vm.Processor, error) {
+		return nil, fmt.Errorf("VM not implemented for program hash %s", programHash)
+	})
+	RegisterFactory(sc3ProgramHash, func(programHash string) (vm.Processor, error) {
+		return nil, fmt.Errorf("VM not implemented for program hash %s", programHash)
+	})
+}
+
 func configure(_ *node.Plugin) {
 	log = logger.NewLogger(PluginName)
 }
@@ -47,39 +64,18 @@ func run(_ *node.Plugin) {
 }
 
 // RegisterProcessor creates and registers processor for program hash
-// asynchronously
-// possibly, locates Wasm program code in IPFS and caches here
+// asynchronously, going through the processor manager: a builtin factory
+// registered at init time if there is one, otherwise Wasm bytecode located
+// in the local cache or, failing that, fetched from IPFS and cached.
 func RegisterProcessor(programHash string, onFinish func(err error)) {
 	go func() {
-		processorsMutex.Lock()
-		defer processorsMutex.Unlock()
-
-		switch programHash {
-		case "KSoWFbHwZuHG8B8HVcYVKR4WYVQ7MpoqeaXgKWfkBMF": // sc1
-			processors[programHash] = vmnil.New()
-			onFinish(nil)
-
-		case "7xmPcECfZsSQq5eq7GCucuxmL2QpsgYwTjusuQcoK9GE": // sc2
-			onFinish(fmt.Errorf("VM not implemented"))
-
-		case "2tx7z36m9EhX3xBRGmEUD4FwTyP6R66zGPYY53EWc87k": // sc3
-			onFinish(fmt.Errorf("VM not implemented"))
-
-		default:
-			onFinish(fmt.Errorf("can't create processor for progam hash %s", programHash))
-		}
+		_, err := procManager.Register(programHash)
+		onFinish(err)
 	}()
 }
 
 func getProcessor(programHash string) (vm.Processor, error) {
-	processorsMutex.RLock()
-	defer processorsMutex.RUnlock()
-
-	ret, ok := processors[programHash]
-	if !ok {
-		return nil, errors.New("no such processor")
-	}
-	return ret, nil
+	return procManager.Get(programHash)
 }
 
 // RunComputationsAsync runs computations for the batch of requests in the background
@@ -0,0 +1,258 @@
+package runvm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/iotaledger/wasp/packages/vm"
+)
+
+// ipfsFetchTimeout bounds how long a single Wasm fetch from the IPFS gateway
+// may take, so a stalled gateway can't block a caller indefinitely.
+const ipfsFetchTimeout = 10 * time.Second
+
+// programHashRegexp matches the base58-like alphanumeric program hashes used
+// throughout the codebase. Anything else is rejected before it is allowed to
+// touch the filesystem (cache path) or be embedded in a URL (IPFS gateway).
+var programHashRegexp = regexp.MustCompile(`^[a-zA-Z0-9]{8,64}$`)
+
+func validateProgramHash(programHash string) error {
+	if !programHashRegexp.MatchString(programHash) {
+		return fmt.Errorf("invalid program hash %q", programHash)
+	}
+	return nil
+}
+
+// ProcessorFactory creates a vm.Processor instance for the given program hash.
+// A factory is expected to either construct a statically linked processor
+// (for builtin/example programs) or load Wasm bytecode and build a
+// processor able to run it.
+type ProcessorFactory func(programHash string) (vm.Processor, error)
+
+// processorManager keeps track of registered factories and instantiated
+// processors, and knows how to locate Wasm bytecode for a program hash
+// that is not handled by any builtin factory.
+type processorManager struct {
+	mutex      sync.RWMutex
+	factories  map[string]ProcessorFactory
+	processors map[string]vm.Processor
+	inFlight   map[string]*inFlightLoad
+	cacheDir   string
+	ipfsGate   string
+	httpClient *http.Client
+}
+
+// inFlightLoad dedupes concurrent instantiate calls for the same program
+// hash: the first caller does the work, everyone else waits on done.
+type inFlightLoad struct {
+	done chan struct{}
+	proc vm.Processor
+	err  error
+}
+
+var procManager = newProcessorManager()
+
+func newProcessorManager() *processorManager {
+	return &processorManager{
+		factories:  make(map[string]ProcessorFactory),
+		processors: make(map[string]vm.Processor),
+		inFlight:   make(map[string]*inFlightLoad),
+		cacheDir:   "wasm_cache",
+		ipfsGate:   "https://ipfs.io/ipfs",
+		httpClient: &http.Client{Timeout: ipfsFetchTimeout},
+	}
+}
+
+// RegisterFactory registers a ProcessorFactory for the given program hash.
+// Plugins call this at init time to provide builtin processors (e.g. vmnil).
+func RegisterFactory(programHash string, factory ProcessorFactory) {
+	procManager.mutex.Lock()
+	defer procManager.mutex.Unlock()
+	procManager.factories[programHash] = factory
+}
+
+// Has returns true if a processor is already instantiated for programHash.
+func (m *processorManager) Has(programHash string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	_, ok := m.processors[programHash]
+	return ok
+}
+
+// Get returns the processor for programHash if it is already instantiated
+// or can be instantiated cheaply from a registered factory. It never loads
+// Wasm bytecode from the cache or IPFS itself, so it's safe to call from a
+// synchronous, latency-sensitive path: a program hash that needs a Wasm
+// fetch must first be loaded via RegisterProcessor or PreloadProcessor.
+func (m *processorManager) Get(programHash string) (vm.Processor, error) {
+	m.mutex.RLock()
+	proc, ok := m.processors[programHash]
+	_, hasFactory := m.factories[programHash]
+	m.mutex.RUnlock()
+	if ok {
+		return proc, nil
+	}
+	if !hasFactory {
+		return nil, fmt.Errorf("no such processor for program hash %s: not loaded, call RegisterProcessor first", programHash)
+	}
+	// go through the same dedup path as Register/Preload: resolve() only
+	// consults the (cheap, non-blocking) factory here, since hasFactory is true.
+	return m.instantiate(programHash)
+}
+
+// Register instantiates the processor for programHash and caches it,
+// loading Wasm bytecode from the cache/IPFS if there is no builtin factory.
+// Concurrent calls for the same programHash are deduped: only one of them
+// does the actual loading.
+func (m *processorManager) Register(programHash string) (vm.Processor, error) {
+	if err := validateProgramHash(programHash); err != nil {
+		return nil, err
+	}
+	return m.instantiate(programHash)
+}
+
+// Evict removes the instantiated processor for programHash, if any, so that
+// the next Register/Preload call reloads it. Get reloads it only when a
+// factory is registered for programHash; otherwise Get returns an error.
+func (m *processorManager) Evict(programHash string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.processors, programHash)
+}
+
+// Preload instantiates and caches the processor for programHash ahead of time.
+func (m *processorManager) Preload(programHash string) error {
+	if err := validateProgramHash(programHash); err != nil {
+		return err
+	}
+	_, err := m.instantiate(programHash)
+	return err
+}
+
+// instantiate resolves programHash to a processor, either through a
+// registered factory or by loading Wasm bytecode, and caches the result.
+// Concurrent calls for the same programHash share a single in-flight load.
+func (m *processorManager) instantiate(programHash string) (vm.Processor, error) {
+	m.mutex.Lock()
+	if proc, ok := m.processors[programHash]; ok {
+		m.mutex.Unlock()
+		return proc, nil
+	}
+	if load, ok := m.inFlight[programHash]; ok {
+		m.mutex.Unlock()
+		<-load.done
+		return load.proc, load.err
+	}
+	load := &inFlightLoad{done: make(chan struct{})}
+	m.inFlight[programHash] = load
+	m.mutex.Unlock()
+
+	load.proc, load.err = m.resolve(programHash)
+
+	m.mutex.Lock()
+	if load.err == nil {
+		m.processors[programHash] = load.proc
+	}
+	delete(m.inFlight, programHash)
+	m.mutex.Unlock()
+
+	close(load.done)
+	return load.proc, load.err
+}
+
+func (m *processorManager) resolve(programHash string) (vm.Processor, error) {
+	m.mutex.RLock()
+	factory, hasFactory := m.factories[programHash]
+	m.mutex.RUnlock()
+
+	if hasFactory {
+		return factory(programHash)
+	}
+	return m.loadWasmProcessor(programHash)
+}
+
+// loadWasmProcessor locates Wasm bytecode for programHash, looking first in
+// the local cache directory and, failing that, fetching it from the
+// configured IPFS gateway (the program hash doubles as the IPFS key), then
+// builds a processor able to run it.
+func (m *processorManager) loadWasmProcessor(programHash string) (vm.Processor, error) {
+	code, err := m.loadFromCache(programHash)
+	if err != nil {
+		code, err = m.loadFromIPFS(programHash)
+		if err != nil {
+			return nil, fmt.Errorf("can't find processor for program hash %s: %v", programHash, err)
+		}
+		if err := m.storeInCache(programHash, code); err != nil {
+			log.Warnf("failed to cache Wasm program %s: %v", programHash, err)
+		}
+	}
+	return newWasmProcessor(programHash, code)
+}
+
+func (m *processorManager) cachePath(programHash string) string {
+	return path.Join(m.cacheDir, programHash+".wasm")
+}
+
+func (m *processorManager) loadFromCache(programHash string) ([]byte, error) {
+	return ioutil.ReadFile(m.cachePath(programHash))
+}
+
+// storeInCache writes code to a temp file in cacheDir and renames it into
+// place, so concurrent writers for the same programHash (or a reader racing
+// a writer) never observe a partially-written .wasm file.
+func (m *processorManager) storeInCache(programHash string, code []byte) error {
+	if err := os.MkdirAll(m.cacheDir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(m.cacheDir, programHash+".wasm.tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(code); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, m.cachePath(programHash))
+}
+
+func (m *processorManager) loadFromIPFS(programHash string) ([]byte, error) {
+	url := m.ipfsGate + "/" + programHash
+	resp, err := m.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("IPFS gateway returned status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// PreloadProcessor instantiates and caches the processor for programHash
+// ahead of time, for example from an admin API or at node startup.
+func PreloadProcessor(programHash string) error {
+	return procManager.Preload(programHash)
+}
+
+// EvictProcessor drops the cached processor instance for programHash, if any.
+func EvictProcessor(programHash string) {
+	procManager.Evict(programHash)
+}
+
+// HasProcessor returns true if a processor is already instantiated and
+// cached for programHash, so the node operator can query loaded state.
+func HasProcessor(programHash string) bool {
+	return procManager.Has(programHash)
+}
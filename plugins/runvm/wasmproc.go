@@ -0,0 +1,25 @@
+package runvm
+
+import (
+	"github.com/iotaledger/wasp/packages/vm"
+)
+
+// wasmProcessor is a vm.Processor backed by Wasm bytecode loaded from the
+// local cache or IPFS. It is a thin placeholder until the Wasm interpreter
+// is wired in; it exists so the processor manager has something to hand
+// back for program hashes that don't have a builtin factory.
+type wasmProcessor struct {
+	programHash string
+	code        []byte
+}
+
+func newWasmProcessor(programHash string, code []byte) (vm.Processor, error) {
+	return &wasmProcessor{
+		programHash: programHash,
+		code:        code,
+	}, nil
+}
+
+func (w *wasmProcessor) Run(ctx *vm.VMContext) {
+	ctx.Log.Errorf("wasm processor for program hash %s: execution not implemented", w.programHash)
+}